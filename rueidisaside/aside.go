@@ -2,6 +2,7 @@ package rueidisaside
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"strings"
 	"sync"
@@ -9,15 +10,36 @@ import (
 
 	"github.com/oklog/ulid/v2"
 	"github.com/redis/rueidis"
+	"golang.org/x/sync/singleflight"
 )
 
 type ClientOption struct {
 	ClientOption rueidis.ClientOption
 	ClientTTL    time.Duration // TTL for the client marker, refreshed every 1/2 TTL. Defaults to 10s. The marker allows other client to know if this client is still alive.
+	// LockNodes, when non-empty, switches the distributed lock backing Get from the
+	// default single-node SET NX scheme to a Redlock quorum across these independent
+	// rueidis.Clients, in addition to the one built from ClientOption. Use this when
+	// the lock must stay correct across a single Redis master failing over.
+	LockNodes  []rueidis.ClientOption
+	LockOption LockOption
+	// Codec marshals and unmarshals values for the generic Get[T]. Defaults to JSONCodec.
+	Codec Codec
+	// Tracer starts a Span around every Get/Del call. Defaults to a no-op Tracer.
+	Tracer Tracer
+	// Meter records rueidisaside_* counters and histograms. Defaults to a no-op Meter.
+	Meter Meter
+	// NegativeTTL, when non-zero, caches a fn result of ErrNotFound for this long
+	// instead of the usual ttl, so repeated misses don't stampede fn. Disabled by default.
+	NegativeTTL time.Duration
+	// RefreshAheadRatio, when non-zero, kicks off an async refill of a key once its
+	// remaining TTL drops below RefreshAheadRatio*ttl, while still serving the current
+	// value to the caller that triggered it. Disabled by default.
+	RefreshAheadRatio float64
 }
 
 type CacheAsideClient interface {
 	Get(ctx context.Context, ttl time.Duration, key string, fn func(ctx context.Context, key string) (val string, err error)) (val string, err error)
+	MGet(ctx context.Context, ttl time.Duration, keys []string, fn func(ctx context.Context, missingKeys []string) (map[string]string, error)) (map[string]string, error)
 	Del(ctx context.Context, key string) error
 	Close()
 }
@@ -26,9 +48,23 @@ func NewClient(option ClientOption) (CacheAsideClient, error) {
 	if option.ClientTTL <= 0 {
 		option.ClientTTL = 10 * time.Second
 	}
+	if option.Codec == nil {
+		option.Codec = JSONCodec{}
+	}
+	if option.Tracer == nil {
+		option.Tracer = noopTracer{}
+	}
+	if option.Meter == nil {
+		option.Meter = noopMeter{}
+	}
 	ca := &Client{
-		waits: make(map[string]chan struct{}),
-		ttl:   option.ClientTTL,
+		waits:             make(map[string]chan struct{}),
+		ttl:               option.ClientTTL,
+		codec:             option.Codec,
+		tracer:            option.Tracer,
+		meter:             option.Meter,
+		negativeTTL:       option.NegativeTTL,
+		refreshAheadRatio: option.RefreshAheadRatio,
 	}
 	option.ClientOption.OnInvalidations = ca.onInvalidation
 	client, err := rueidis.NewClient(option.ClientOption)
@@ -36,19 +72,45 @@ func NewClient(option ClientOption) (CacheAsideClient, error) {
 		return nil, err
 	}
 	ca.client = client
+	if len(option.LockNodes) == 0 {
+		ca.locker = newSingleNodeLocker(client)
+	} else {
+		nodes := make([]rueidis.Client, 0, len(option.LockNodes)+1)
+		nodes = append(nodes, client)
+		for _, no := range option.LockNodes {
+			node, err := rueidis.NewClient(no)
+			if err != nil {
+				for _, n := range nodes {
+					n.Close()
+				}
+				return nil, err
+			}
+			nodes = append(nodes, node)
+			ca.extra = append(ca.extra, node)
+		}
+		ca.locker = newRedlockLocker(nodes, option.LockOption)
+	}
 	ca.ctx, ca.cancel = context.WithCancel(context.Background())
 
 	return ca, nil
 }
 
 type Client struct {
-	client rueidis.Client
-	id     string
-	waits  map[string]chan struct{}
-	ctx    context.Context
-	cancel context.CancelFunc
-	ttl    time.Duration
-	mu     sync.Mutex
+	client            rueidis.Client
+	locker            Locker
+	codec             Codec
+	tracer            Tracer
+	meter             Meter
+	extra             []rueidis.Client // additional lock nodes to close alongside client, when using a Redlock quorum
+	negativeTTL       time.Duration
+	refreshAheadRatio float64
+	id                string
+	waits             map[string]chan struct{}
+	ctx               context.Context
+	cancel            context.CancelFunc
+	ttl               time.Duration
+	mu                sync.Mutex
+	sf                singleflight.Group
 }
 
 func (c *Client) onInvalidation(messages []rueidis.RedisMessage) {
@@ -96,7 +158,9 @@ func (c *Client) refresh(id string) {
 			if id2 != id {
 				return // client id has changed, abort this goroutine
 			}
-			c.client.Do(c.ctx, c.client.B().Set().Key(id).Value("").Px(c.ttl).Build())
+			if err := c.locker.Refresh(c.ctx, id, c.ttl); err != nil {
+				c.meter.IncKeepaliveFailure()
+			}
 		case <-c.ctx.Done():
 			return
 		}
@@ -109,7 +173,7 @@ func (c *Client) keepalive() (id string, err error) {
 	c.mu.Unlock()
 	if id == "" {
 		id = PlaceholderPrefix + ulid.Make().String()
-		if err = c.client.Do(c.ctx, c.client.B().Set().Key(id).Value("").Px(c.ttl).Build()).Error(); err == nil {
+		if err = c.locker.Refresh(c.ctx, id, c.ttl); err == nil {
 			c.mu.Lock()
 			if c.id == "" {
 				c.id = id
@@ -118,58 +182,126 @@ func (c *Client) keepalive() (id string, err error) {
 				id = c.id
 			}
 			c.mu.Unlock()
+		} else {
+			c.meter.IncKeepaliveFailure()
 		}
 	}
 	return id, err
 }
 
+// Get coordinates concurrent fillers both across processes, via the redis lock and
+// client-side caching invalidations, and within this process, by coalescing concurrent
+// calls for the same key into a single DoCache/lock/fn pipeline through singleflight.
+// A caller whose ctx is canceled only stops waiting on its own result; it never cancels
+// the in-flight pipeline shared with the other callers.
 func (c *Client) Get(ctx context.Context, ttl time.Duration, key string, fn func(ctx context.Context, key string) (val string, err error)) (string, error) {
+	ch := c.sf.DoChan(key, func() (any, error) {
+		// Only the leader's closure ever runs, so it's this call's own ctx that
+		// feeds the pipeline. Detach its cancellation and deadline, since a
+		// canceled follower must not cancel the leader, but keep its values:
+		// followers can't see them, but the leader's auth/trace/request-scoped
+		// values shouldn't be silently dropped just because it won the race.
+		sctx, cancel := context.WithTimeout(detachedContext{ctx}, ttl)
+		defer cancel()
+		return c.get(sctx, ttl, key, fn)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *Client) get(ctx context.Context, ttl time.Duration, key string, fn func(ctx context.Context, key string) (val string, err error)) (string, error) {
+	ctx, span := c.tracer.Start(ctx, "rueidisaside.Get")
+	defer span.End()
 	ctx, cancel := context.WithTimeout(ctx, ttl)
 	defer cancel()
+	var lockedByOther, fillerRan bool
 retry:
 	wait := c.register(key)
 	resp := c.client.DoCache(ctx, c.client.B().Get().Key(key).Cache(), ttl)
 	val, err := resp.ToString()
+	if err == nil && val == negativeMarker { // a previously-cached miss, within its NegativeTTL
+		span.SetAttributes(map[string]any{"cache.key": key, "cache.ttl_ms": ttl.Milliseconds(), "cache.hit": true, "cache.locked_by_other": false, "cache.filler_ran": false})
+		c.meter.IncGet("hit")
+		return "", ErrNotFound
+	}
 	if rueidis.IsRedisNil(err) && fn != nil { // cache miss, prepare to populate the value by fn()
 		var id string
 		if id, err = c.keepalive(); err == nil { // acquire client id
-			val, err = c.client.Do(ctx, c.client.B().Set().Key(key).Value(id).Nx().Get().Px(ttl).Build()).ToString()
-			if rueidis.IsRedisNil(err) { // successfully set client id on the key as a lock
-				if val, err = fn(ctx, key); err == nil {
+			val, err = c.locker.TryAcquire(ctx, key, id, ttl)
+			if err == nil && val == "" { // successfully acquired the lock on key
+				fillerRan = true
+				start := time.Now()
+				val, err = fn(ctx, key)
+				c.meter.ObserveFillDuration(time.Since(start))
+				if err == nil {
 					err = setkey.Exec(ctx, c.client, []string{key}, []string{id, val, strconv.FormatInt(ttl.Milliseconds(), 10)}).Error()
+				} else if errors.Is(err, ErrNotFound) && c.negativeTTL > 0 {
+					if cacheErr := setkey.Exec(ctx, c.client, []string{key}, []string{id, negativeMarker, strconv.FormatInt(c.negativeTTL.Milliseconds(), 10)}).Error(); cacheErr != nil {
+						c.locker.Release(context.Background(), key, id)
+					}
+					span.SetAttributes(map[string]any{"cache.key": key, "cache.ttl_ms": ttl.Milliseconds(), "cache.hit": false, "cache.locked_by_other": false, "cache.filler_ran": true})
+					c.meter.IncGet("miss")
+					return "", err
 				}
 				if err != nil { // failed to populate the value, release the lock.
-					delkey.Exec(context.Background(), c.client, []string{key}, []string{id})
+					c.locker.Release(context.Background(), key, id)
 				}
+			} else if err == nil {
+				lockedByOther = true
 			}
 		}
 	}
 	if err != nil {
+		span.SetAttributes(map[string]any{"cache.key": key, "cache.ttl_ms": ttl.Milliseconds(), "cache.hit": false, "cache.locked_by_other": lockedByOther, "cache.filler_ran": fillerRan})
+		c.meter.IncGet("error")
 		return val, err
 	}
 	if strings.HasPrefix(val, PlaceholderPrefix) {
+		lockedByOther = true
 		ph := c.register(val)
 		err = c.client.DoCache(ctx, c.client.B().Get().Key(val).Cache(), c.ttl).Error()
 		if rueidis.IsRedisNil(err) {
 			// the client who held the lock has gone, release the lock.
-			delkey.Exec(context.Background(), c.client, []string{key}, []string{val})
+			c.locker.Release(context.Background(), key, val)
 			goto retry
 		}
 		val = ""
 		if err == nil {
+			waitStart := time.Now()
 			select {
 			case <-ph:
 			case <-wait:
 			case <-ctx.Done():
+				span.SetAttributes(map[string]any{"cache.key": key, "cache.ttl_ms": ttl.Milliseconds(), "cache.hit": false, "cache.locked_by_other": lockedByOther, "cache.filler_ran": fillerRan})
+				c.meter.IncGet("error")
 				return "", ctx.Err()
 			}
+			c.meter.ObserveLockWait(time.Since(waitStart))
+			c.meter.IncGet("wait")
 			goto retry
 		}
 	}
+	span.SetAttributes(map[string]any{"cache.key": key, "cache.ttl_ms": ttl.Milliseconds(), "cache.hit": !fillerRan, "cache.locked_by_other": lockedByOther, "cache.filler_ran": fillerRan})
+	if fillerRan {
+		c.meter.IncGet("miss")
+	} else {
+		c.meter.IncGet("hit")
+		c.maybeRefreshAhead(ttl, key, fn)
+	}
 	return val, err
 }
 
 func (c *Client) Del(ctx context.Context, key string) error {
+	ctx, span := c.tracer.Start(ctx, "rueidisaside.Del")
+	defer span.End()
+	span.SetAttributes(map[string]any{"cache.key": key})
 	return c.client.Do(ctx, c.client.B().Del().Key(key).Build()).Error()
 }
 
@@ -182,8 +314,25 @@ func (c *Client) Close() {
 		c.client.Do(context.Background(), c.client.B().Del().Key(c.id).Build())
 	}
 	c.client.Close()
+	for _, n := range c.extra {
+		n.Close()
+	}
 }
 
+// detachedContext wraps a context.Context, keeping its values reachable via
+// Value while discarding its cancellation signal and deadline. It lets the
+// singleflight leader's pipeline run to completion on a fresh ttl-scoped
+// timeout without losing request-scoped values (auth tokens, trace IDs, ...)
+// carried by whichever caller's ctx happened to win the race to lead.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (d detachedContext) Value(key any) any         { return d.parent.Value(key) }
+
 const PlaceholderPrefix = "rueidisid:"
 
 var (
@@ -0,0 +1,51 @@
+package rueidisaside
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals and unmarshals the values stored by the generic Get[T].
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes values with encoding/json. It is the default Codec when
+// ClientOption.Codec is left unset.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// MessagePackCodec encodes values with MessagePack, which is typically smaller
+// and cheaper to (de)serialize than JSON for structured values.
+type MessagePackCodec struct{}
+
+func (MessagePackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+func (MessagePackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// ProtobufCodec encodes values with the protobuf wire format. The value passed
+// to Marshal and the pointer passed to Unmarshal must implement proto.Message.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("rueidisaside: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("rueidisaside: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,64 @@
+package rueidisaside
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type codecTestValue struct {
+	A string
+	B int
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		codec Codec
+	}{
+		{"JSON", JSONCodec{}},
+		{"MessagePack", MessagePackCodec{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := codecTestValue{A: "hello", B: 42}
+			b, err := tc.codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var out codecTestValue
+			if err := tc.codec.Unmarshal(b, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if out != in {
+				t.Fatalf("got %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestProtobufCodecRoundTrip(t *testing.T) {
+	c := ProtobufCodec{}
+	in := wrapperspb.String("hello")
+	b, err := c.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	out := &wrapperspb.StringValue{}
+	if err := c.Unmarshal(b, out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.GetValue() != in.GetValue() {
+		t.Fatalf("got %q, want %q", out.GetValue(), in.GetValue())
+	}
+}
+
+func TestProtobufCodecRejectsNonProtoMessage(t *testing.T) {
+	c := ProtobufCodec{}
+	if _, err := c.Marshal("not a proto.Message"); err == nil {
+		t.Fatal("Marshal: expected an error for a non-proto.Message value")
+	}
+	if err := c.Unmarshal([]byte("x"), new(string)); err == nil {
+		t.Fatal("Unmarshal: expected an error for a non-proto.Message target")
+	}
+}
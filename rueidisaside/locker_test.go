@@ -0,0 +1,129 @@
+package rueidisaside
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// TestRedlockValidity covers the Redlock validity-window arithmetic in
+// isolation: ttl minus elapsed minus the drift budget, with no node I/O
+// involved.
+func TestRedlockValidity(t *testing.T) {
+	cases := []struct {
+		name        string
+		ttl         time.Duration
+		elapsed     time.Duration
+		driftFactor float64
+		driftMargin time.Duration
+		wantExpired bool
+	}{
+		{"fast acquire leaves a window", time.Second, 10 * time.Millisecond, 0.01, 2 * time.Millisecond, false},
+		{"elapsed alone exceeds ttl", time.Second, 2 * time.Second, 0.01, 2 * time.Millisecond, true},
+		{"drift budget tips it over", 100 * time.Millisecond, 95 * time.Millisecond, 0.01, 5 * time.Millisecond, true},
+		{"zero elapsed, only drift budget spent", time.Second, 0, 0.5, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := redlockValidity(tc.ttl, tc.elapsed, tc.driftFactor, tc.driftMargin)
+			if expired := got <= 0; expired != tc.wantExpired {
+				t.Fatalf("redlockValidity(%v, %v, %v, %v) = %v, expired = %v, want %v",
+					tc.ttl, tc.elapsed, tc.driftFactor, tc.driftMargin, got, expired, tc.wantExpired)
+			}
+		})
+	}
+}
+
+// TestNewRedlockLockerQuorumDefault covers the default-quorum computation:
+// a majority of the node count, unless LockOption.Quorum overrides it.
+func TestNewRedlockLockerQuorumDefault(t *testing.T) {
+	cases := []struct {
+		nodes  int
+		option LockOption
+		want   int
+	}{
+		{3, LockOption{}, 2},
+		{5, LockOption{}, 3},
+		{1, LockOption{}, 1},
+		{5, LockOption{Quorum: 1}, 1},
+	}
+	for _, tc := range cases {
+		l := newRedlockLocker(make([]rueidis.Client, tc.nodes), tc.option)
+		if l.quorum != tc.want {
+			t.Fatalf("newRedlockLocker(%d nodes, %+v).quorum = %d, want %d", tc.nodes, tc.option, l.quorum, tc.want)
+		}
+	}
+}
+
+// newRedlockNodes dials n connections to the same local Redis, each on its
+// own logical database so that every node holds its own copy of a lock key.
+// They aren't truly independent masters, but they exercise the real
+// per-node SET NX GET / DEL wire protocol and let TryAcquire's quorum
+// counting run against genuine responses rather than a fake.
+func newRedlockNodes(t *testing.T, n int) []rueidis.Client {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("no redis in -short mode")
+	}
+	nodes := make([]rueidis.Client, n)
+	for i := range nodes {
+		c, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{"127.0.0.1:6379"}, SelectDB: i, DisableCache: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(c.Close)
+		nodes[i] = c
+	}
+	return nodes
+}
+
+func TestRedlockLockerTryAcquireAndRelease(t *testing.T) {
+	nodes := newRedlockNodes(t, 3)
+	l := newRedlockLocker(nodes, LockOption{})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+
+	prev, err := l.TryAcquire(ctx, key, "holder-a", time.Minute)
+	if err != nil || prev != "" {
+		t.Fatalf("first TryAcquire: prev=%q err=%v, want empty prev and no error", prev, err)
+	}
+
+	prev, err = l.TryAcquire(ctx, key, "holder-b", time.Minute)
+	if err != nil || prev != "holder-a" {
+		t.Fatalf("contended TryAcquire: prev=%q err=%v, want prev=holder-a", prev, err)
+	}
+
+	if err := l.Release(ctx, key, "holder-a"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	prev, err = l.TryAcquire(ctx, key, "holder-b", time.Minute)
+	if err != nil || prev != "" {
+		t.Fatalf("TryAcquire after release: prev=%q err=%v, want empty prev and no error", prev, err)
+	}
+	l.Release(ctx, key, "holder-b")
+}
+
+func TestRedlockLockerExpiresUnderImpossibleTTL(t *testing.T) {
+	nodes := newRedlockNodes(t, 3)
+	l := newRedlockLocker(nodes, LockOption{})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+
+	// A ttl shorter than the drift margin leaves no validity window no
+	// matter how fast the nodes respond, so quorum is reached but the lock
+	// is still rejected and released everywhere. 1ms is the shortest ttl
+	// PX accepts; anything below it is sent as PX 0, which Redis rejects.
+	_, err := l.TryAcquire(ctx, key, "holder", time.Millisecond)
+	if err != ErrLockExpired {
+		t.Fatalf("TryAcquire with impossible ttl: err=%v, want ErrLockExpired", err)
+	}
+
+	prev, err := l.TryAcquire(ctx, key, "holder-2", time.Minute)
+	if err != nil || prev != "" {
+		t.Fatalf("TryAcquire after expired acquire: prev=%q err=%v, want empty prev (lock must have been released)", prev, err)
+	}
+	l.Release(ctx, key, "holder-2")
+}
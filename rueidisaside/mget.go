@@ -0,0 +1,160 @@
+package rueidisaside
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// MGet batches cache-aside lookups across multiple keys: a single pipelined
+// DoMultiCache for all keys, followed by locking only the keys that genuinely
+// missed. Keys already locked by another client fall back to the existing
+// wait-on-placeholder path used by Get. fn is invoked at most once with the
+// keys this caller acquired the lock on, and those results are written back
+// with a single pipelined Lua exec. A key on the fallback path is handled by
+// Get, which calls fn again with that key alone if it ends up filling it.
+func (c *Client) MGet(ctx context.Context, ttl time.Duration, keys []string, fn func(ctx context.Context, missingKeys []string) (map[string]string, error)) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ttl)
+	defer cancel()
+
+	result := make(map[string]string, len(keys))
+	commands := make([]rueidis.CacheableTTL, len(keys))
+	for i, key := range keys {
+		commands[i] = rueidis.CT(c.client.B().Get().Key(key).Cache(), ttl)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	fallback := func(key string) {
+		defer wg.Done()
+		val, err := c.Get(ctx, ttl, key, func(ctx context.Context, key string) (string, error) {
+			m, err := fn(ctx, []string{key})
+			if err != nil {
+				return "", err
+			}
+			return m[key], nil
+		})
+		if err != nil {
+			fail(err)
+			return
+		}
+		mu.Lock()
+		result[key] = val
+		mu.Unlock()
+	}
+
+	type ownedKey struct {
+		key string
+		id  string
+	}
+	var owned []ownedKey
+
+	var missing []string
+	for i, resp := range c.client.DoMultiCache(ctx, commands...) {
+		key := keys[i]
+		val, err := resp.ToString()
+		switch {
+		case rueidis.IsRedisNil(err):
+			missing = append(missing, key)
+		case err != nil:
+			fail(err)
+		case strings.HasPrefix(val, PlaceholderPrefix):
+			// locked by another client; wait on its fill through Get.
+			wg.Add(1)
+			go fallback(key)
+		default:
+			mu.Lock()
+			result[key] = val
+			mu.Unlock()
+		}
+	}
+
+	// Lock acquisition for every missing key is pipelined into a single round
+	// trip per node (see Locker.TryAcquireMulti), rather than one round trip
+	// per key, so a list view with many misses doesn't pay N sequential
+	// lock round trips.
+	if len(missing) > 0 {
+		id, err := c.keepalive()
+		if err != nil {
+			fail(err)
+		} else {
+			prevs, errs := c.locker.TryAcquireMulti(ctx, missing, id, ttl)
+			for i, key := range missing {
+				if errs[i] != nil {
+					fail(errs[i])
+					continue
+				}
+				if prevs[i] == "" { // acquired the lock on key; this caller fills it.
+					owned = append(owned, ownedKey{key: key, id: id})
+					continue
+				}
+				// locked by someone else, or already populated under a
+				// placeholder; fall back to the regular wait-on-placeholder
+				// path for this key.
+				wg.Add(1)
+				go fallback(key)
+			}
+		}
+	}
+
+	if len(owned) > 0 {
+		if firstErr != nil {
+			// a prior key in the scan above failed outright; this caller is not
+			// going to call fn, so don't sit on locks it already acquired.
+			for _, o := range owned {
+				c.locker.Release(context.Background(), o.key, o.id)
+			}
+		} else {
+			ownKeys := make([]string, len(owned))
+			for i, o := range owned {
+				ownKeys[i] = o.key
+			}
+			filled, err := fn(ctx, ownKeys)
+			if err != nil {
+				for _, o := range owned {
+					c.locker.Release(context.Background(), o.key, o.id)
+				}
+				fail(err)
+			} else {
+				execs := make([]rueidis.LuaExec, 0, len(owned))
+				for _, o := range owned {
+					val, ok := filled[o.key]
+					if !ok { // fn didn't fill this key after all, release its lock.
+						c.locker.Release(context.Background(), o.key, o.id)
+						continue
+					}
+					mu.Lock()
+					result[o.key] = val
+					mu.Unlock()
+					execs = append(execs, rueidis.LuaExec{
+						Keys: []string{o.key},
+						Args: []string{o.id, val, strconv.FormatInt(ttl.Milliseconds(), 10)},
+					})
+				}
+				for _, resp := range setkey.ExecMulti(ctx, c.client, execs...) {
+					if err := resp.Error(); err != nil {
+						fail(err)
+					}
+				}
+			}
+		}
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
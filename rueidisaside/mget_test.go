@@ -0,0 +1,186 @@
+package rueidisaside
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// These are integration tests against a real Redis, following the same
+// convention as rueidislock's tests: a local server at 127.0.0.1:6379,
+// skipped under `go test -short` for environments without one.
+
+func newMGetTestClient(t *testing.T) *Client {
+	return newTestClient(t, ClientOption{})
+}
+
+// TestMGetPartialMiss covers a single MGet call mixing a straight cache hit
+// (the unguarded write review flagged at the old "default:" case) with a
+// key this caller ends up owning and filling (the owned-key commit loop).
+// Both write into the shared result map, here from the calling goroutine
+// only; the concurrent case is TestMGetPartialLockContention.
+func TestMGetPartialMiss(t *testing.T) {
+	c := newMGetTestClient(t)
+	ctx := context.Background()
+	keys := uniqueKeys(t, 2)
+	hitKey, missKey := keys[0], keys[1]
+
+	if _, err := c.Get(ctx, time.Minute, hitKey, func(ctx context.Context, key string) (string, error) {
+		return "pre-populated", nil
+	}); err != nil {
+		t.Fatalf("seeding %q: %v", hitKey, err)
+	}
+
+	result, err := c.MGet(ctx, time.Minute, []string{hitKey, missKey}, func(ctx context.Context, missingKeys []string) (map[string]string, error) {
+		m := make(map[string]string, len(missingKeys))
+		for _, k := range missingKeys {
+			m[k] = "filled:" + k
+		}
+		return m, nil
+	})
+	if err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if result[hitKey] != "pre-populated" {
+		t.Errorf("result[%q] = %q, want %q", hitKey, result[hitKey], "pre-populated")
+	}
+	if want := "filled:" + missKey; result[missKey] != want {
+		t.Errorf("result[%q] = %q, want %q", missKey, result[missKey], want)
+	}
+}
+
+// TestMGetPartialLockContention covers a key locked by another client mixed
+// with a straight cache hit. The hit writes into result directly while the
+// contended key resolves through Get's wait-on-placeholder fallback, which
+// writes into the same map from its own goroutine once the other client
+// finishes — the exact combination (unguarded hit-branch write racing a
+// fallback goroutine's write) the review's -race concern was about.
+func TestMGetPartialLockContention(t *testing.T) {
+	c := newMGetTestClient(t)
+	ctx := context.Background()
+	keys := uniqueKeys(t, 2)
+	hitKey, contendedKey := keys[0], keys[1]
+
+	if _, err := c.Get(ctx, time.Minute, hitKey, func(ctx context.Context, key string) (string, error) {
+		return "pre-populated", nil
+	}); err != nil {
+		t.Fatalf("seeding %q: %v", hitKey, err)
+	}
+
+	otherID := PlaceholderPrefix + "other-client"
+	if err := c.locker.Refresh(ctx, otherID, time.Minute); err != nil {
+		t.Fatalf("seeding other client's marker: %v", err)
+	}
+	if prev, err := c.locker.TryAcquire(ctx, contendedKey, otherID, time.Minute); err != nil || prev != "" {
+		t.Fatalf("seeding lock on %q: prev=%q err=%v", contendedKey, prev, err)
+	}
+	go func() {
+		// simulate the other client finishing its fill shortly after,
+		// well within this test's MGet ttl.
+		time.Sleep(30 * time.Millisecond)
+		setkey.Exec(context.Background(), c.client, []string{contendedKey}, []string{otherID, "other-filled", strconv.FormatInt(time.Minute.Milliseconds(), 10)})
+	}()
+
+	result, err := c.MGet(ctx, 2*time.Second, []string{hitKey, contendedKey}, func(ctx context.Context, missingKeys []string) (map[string]string, error) {
+		t.Fatalf("fn should not run: neither key is this caller's to fill, got %v", missingKeys)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if result[hitKey] != "pre-populated" {
+		t.Errorf("result[%q] = %q, want %q", hitKey, result[hitKey], "pre-populated")
+	}
+	if result[contendedKey] != "other-filled" {
+		t.Errorf("result[%q] = %q, want %q", contendedKey, result[contendedKey], "other-filled")
+	}
+}
+
+// TestMGetAbandonedLockReleased covers a key that fails outright during the
+// scan phase (here, a WRONGTYPE error from a key holding the wrong kind of
+// value) alongside an earlier-scanned key this caller already acquired the
+// lock on. The fix requires the already-owned lock to be released instead
+// of left to sit until its TTL expires.
+func TestMGetAbandonedLockReleased(t *testing.T) {
+	c := newMGetTestClient(t)
+	ctx := context.Background()
+	keys := uniqueKeys(t, 2)
+	ownedKey, badKey := keys[0], keys[1]
+
+	if err := c.client.Do(ctx, c.client.B().Lpush().Key(badKey).Element("not-a-string").Build()).Error(); err != nil {
+		t.Fatalf("seeding wrong-type %q: %v", badKey, err)
+	}
+
+	fnCalled := false
+	_, err := c.MGet(ctx, time.Minute, []string{ownedKey, badKey}, func(ctx context.Context, missingKeys []string) (map[string]string, error) {
+		fnCalled = true
+		m := make(map[string]string, len(missingKeys))
+		for _, k := range missingKeys {
+			m[k] = "filled:" + k
+		}
+		return m, nil
+	})
+	if err == nil {
+		t.Fatal("MGet: expected an error from the WRONGTYPE key, got nil")
+	}
+	if fnCalled {
+		t.Fatal("fn should not run: the scan phase failed before the owned-key commit step")
+	}
+
+	// The lock this caller acquired on ownedKey must have been released,
+	// not abandoned. If it's still held, re-acquiring it under a fresh id
+	// fails (prev would be this caller's own id instead of "").
+	freshID := PlaceholderPrefix + "fresh-probe"
+	prev, acquireErr := c.locker.TryAcquire(ctx, ownedKey, freshID, time.Minute)
+	if acquireErr != nil {
+		t.Fatalf("re-acquiring %q: %v", ownedKey, acquireErr)
+	}
+	if prev != "" {
+		t.Fatalf("lock on %q was abandoned instead of released (prev=%q)", ownedKey, prev)
+	}
+	c.locker.Release(context.Background(), ownedKey, freshID)
+}
+
+// TestMGetCtxCancel covers a caller's ctx being canceled while fn is still
+// running for a key this caller owns: fn must observe the cancellation
+// (MGet derives its working ctx from the caller's), and the lock already
+// acquired must be released rather than left to expire on its own.
+func TestMGetCtxCancel(t *testing.T) {
+	c := newMGetTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	keys := uniqueKeys(t, 1)
+	key := keys[0]
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	started := make(chan struct{})
+	go func() {
+		defer wg.Done()
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	<-started
+
+	_, err := c.MGet(ctx, time.Minute, []string{key}, func(ctx context.Context, missingKeys []string) (map[string]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	wg.Wait()
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("MGet: got err=%v, want context.Canceled", err)
+	}
+
+	freshID := PlaceholderPrefix + "fresh-probe"
+	prev, acquireErr := c.locker.TryAcquire(context.Background(), key, freshID, time.Minute)
+	if acquireErr != nil {
+		t.Fatalf("re-acquiring %q: %v", key, acquireErr)
+	}
+	if prev != "" {
+		t.Fatalf("lock on %q was abandoned instead of released after ctx cancellation (prev=%q)", key, prev)
+	}
+	c.locker.Release(context.Background(), key, freshID)
+}
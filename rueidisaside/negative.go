@@ -0,0 +1,71 @@
+package rueidisaside
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by fn to tell Get that key has no value. When
+// ClientOption.NegativeTTL is set, the miss itself is cached for that TTL so
+// that concurrent and subsequent callers keep seeing ErrNotFound without
+// re-running fn, protecting the backend from a thundering herd of lookups
+// that all resolve to nothing.
+var ErrNotFound = errors.New("rueidisaside: not found")
+
+// negativeMarker is stored in place of a real value to record a cached miss.
+// It can't collide with a real value written through setkey, since Get never
+// writes a value coming from anywhere but fn or this package.
+const negativeMarker = "\x00rueidisaside:notfound"
+
+// refreshAheadPrefix namespaces the debounce lock maybeRefreshAhead acquires
+// before refilling key. It can't reuse Locker.TryAcquire directly against key
+// itself: TryAcquire is a SET NX, which only succeeds when key is absent, but
+// maybeRefreshAhead only ever runs against a key that's already populated
+// with the real value it just read the PTTL of. Locking this derived key
+// instead gives the same "only one refresher proceeds" guarantee without
+// requiring key to be empty.
+const refreshAheadPrefix = "rueidisaside:refreshing:"
+
+// maybeRefreshAhead kicks off an async refill of key when its remaining TTL
+// has dropped below ClientOption.RefreshAheadRatio of ttl. The caller already
+// has a fresh value to return; this only shortens the window before the next
+// caller would otherwise observe a miss. At most one refresh per key runs at
+// a time, debounced via a short-lived marker distinct from key (see
+// refreshAheadPrefix); the refill itself is a plain SET, not the CAS Get uses
+// to populate a miss, since there's no lock-holder id recorded at key to
+// compare against.
+func (c *Client) maybeRefreshAhead(ttl time.Duration, key string, fn func(ctx context.Context, key string) (string, error)) {
+	if c.refreshAheadRatio <= 0 || fn == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ttl)
+		defer cancel()
+		pttl, err := c.client.Do(ctx, c.client.B().Pttl().Key(key).Build()).ToInt64()
+		if err != nil || pttl < 0 {
+			return
+		}
+		if time.Duration(pttl)*time.Millisecond > time.Duration(float64(ttl)*c.refreshAheadRatio) {
+			return // still fresh enough
+		}
+		id, err := c.keepalive()
+		if err != nil {
+			return
+		}
+		refreshKey := refreshAheadPrefix + key
+		prev, err := c.locker.TryAcquire(ctx, refreshKey, id, ttl)
+		if err != nil || prev != "" {
+			return // someone else already refreshed, or is refreshing it
+		}
+		defer c.locker.Release(context.Background(), refreshKey, id)
+		val, err := fn(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) && c.negativeTTL > 0 {
+				c.client.Do(ctx, c.client.B().Set().Key(key).Value(negativeMarker).Px(c.negativeTTL).Build())
+			}
+			return
+		}
+		c.client.Do(ctx, c.client.B().Set().Key(key).Value(val).Px(ttl).Build())
+	}()
+}
@@ -0,0 +1,91 @@
+package rueidisaside
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNegativeCachingSuppressesFiller covers the core claim of negative
+// caching: once fn reports ErrNotFound, a subsequent Get within NegativeTTL
+// must see ErrNotFound again without invoking fn a second time.
+func TestNegativeCachingSuppressesFiller(t *testing.T) {
+	c := newTestClient(t, ClientOption{NegativeTTL: time.Minute})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+
+	var calls int32
+	fn := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", ErrNotFound
+	}
+
+	if _, err := c.Get(ctx, time.Minute, key, fn); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("first Get: got err=%v, want ErrNotFound", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("fn called %d times after first Get, want 1", n)
+	}
+
+	if _, err := c.Get(ctx, time.Minute, key, fn); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Get: got err=%v, want ErrNotFound", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("fn called %d times after second Get, want 1 (negative cache should have suppressed it)", n)
+	}
+}
+
+// TestMaybeRefreshAheadRefills covers the bug under review: TryAcquire is a
+// SET NX, which can never succeed against a key maybeRefreshAhead only ever
+// sees already populated, so the original implementation silently never
+// invoked fn. With the debounce moved to a distinct refreshAheadPrefix key,
+// a Get made while the cached value is within RefreshAheadRatio of expiry
+// must trigger an async refill that's observable once it lands.
+func TestMaybeRefreshAheadRefills(t *testing.T) {
+	c := newTestClient(t, ClientOption{RefreshAheadRatio: 0.9})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+	// ttl outlasts the polling deadline below, so "refreshed" can only come
+	// from refresh-ahead, never from an ordinary refill after expiry.
+	ttl := 5 * time.Second
+
+	var calls int32
+	valueFor := func(n int32) string {
+		if n == 1 {
+			return "initial"
+		}
+		return "refreshed"
+	}
+	fn := func(ctx context.Context, key string) (string, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return valueFor(n), nil
+	}
+
+	val, err := c.Get(ctx, ttl, key, fn)
+	if err != nil {
+		t.Fatalf("seeding Get: %v", err)
+	}
+	if val != "initial" {
+		t.Fatalf("seeding Get: val = %q, want %q", val, "initial")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("fn called %d times after seeding Get, want 1", n)
+	}
+
+	// RefreshAheadRatio is 0.9, so this hit, close enough to ttl's end,
+	// should trigger maybeRefreshAhead's async refill.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		val, err = c.Get(ctx, ttl, key, fn)
+		if err != nil {
+			t.Fatalf("polling Get: %v", err)
+		}
+		if val == "refreshed" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("refresh-ahead never refilled %q within the deadline; last val = %q, fn calls = %d", key, val, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,56 @@
+package rueidisaside
+
+import (
+	"context"
+	"time"
+)
+
+// Span is the minimal span interface Client uses to annotate a Get call.
+// Implementations typically wrap an OpenTelemetry span.
+type Span interface {
+	SetAttributes(attrs map[string]any)
+	End()
+}
+
+// Tracer starts a Span for each Get/Del call. ClientOption.Tracer defaults to
+// a no-op tracer, so tracing is entirely opt-in and carries no hard
+// dependency on an OpenTelemetry SDK.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Meter records the counters and histograms Client emits. ClientOption.Meter
+// defaults to a no-op meter and carries no hard dependency on a Prometheus
+// client. Implementations typically back these onto
+// rueidisaside_get_total{result=hit|miss|wait|error},
+// rueidisaside_fill_duration_seconds, rueidisaside_lock_wait_seconds and
+// rueidisaside_keepalive_failures_total.
+type Meter interface {
+	// IncGet increments rueidisaside_get_total for result, one of "hit",
+	// "miss", "wait" or "error".
+	IncGet(result string)
+	// ObserveFillDuration records rueidisaside_fill_duration_seconds, the time
+	// spent running fn to populate a missing key.
+	ObserveFillDuration(d time.Duration)
+	// ObserveLockWait records rueidisaside_lock_wait_seconds, the time spent
+	// waiting on another client's in-flight fill.
+	ObserveLockWait(d time.Duration)
+	// IncKeepaliveFailure increments rueidisaside_keepalive_failures_total.
+	IncKeepaliveFailure()
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) { return ctx, noopSpan{} }
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(map[string]any) {}
+func (noopSpan) End()                         {}
+
+type noopMeter struct{}
+
+func (noopMeter) IncGet(string)                     {}
+func (noopMeter) ObserveFillDuration(time.Duration) {}
+func (noopMeter) ObserveLockWait(time.Duration)     {}
+func (noopMeter) IncKeepaliveFailure()              {}
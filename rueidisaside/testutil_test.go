@@ -0,0 +1,40 @@
+package rueidisaside
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTestClient builds a Client against a real local Redis, following the
+// same convention as rueidislock's own tests: a server at 127.0.0.1:6379,
+// skipped under `go test -short` for environments without one. opt is
+// layered over defaults suitable for fast, short-TTL tests.
+func newTestClient(t *testing.T, opt ClientOption) *Client {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("no redis in -short mode")
+	}
+	if len(opt.ClientOption.InitAddress) == 0 {
+		opt.ClientOption.InitAddress = []string{"127.0.0.1:6379"}
+	}
+	if opt.ClientTTL == 0 {
+		opt.ClientTTL = 50 * time.Millisecond
+	}
+	ca, err := NewClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ca.(*Client)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func uniqueKeys(t *testing.T, n int) []string {
+	t.Helper()
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("rueidisaside-test-%s-%d", t.Name(), i)
+	}
+	return keys
+}
@@ -0,0 +1,57 @@
+package rueidisaside
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// nilTag and valueTag prefix the string stored by Get[T], so that a filler
+// returning the zero value of T can be cached as an explicit nil-result marker
+// instead of the codec's encoding of that zero value. Without this, a filler
+// that legitimately has nothing to return would be invoked again on every
+// subsequent miss within ttl.
+const (
+	nilTag   byte = 0
+	valueTag byte = 1
+)
+
+// Get is a generic, Codec-based counterpart to Client.Get. It marshals the
+// value returned by fn with client.codec (ClientOption.Codec, JSONCodec by
+// default) before storing it, and unmarshals it back into T on a hit,
+// sparing callers the string (de)serialization Client.Get otherwise forces
+// on them.
+func Get[T any](ctx context.Context, client *Client, ttl time.Duration, key string, fn func(ctx context.Context, key string) (T, error)) (T, error) {
+	var zero T
+	raw, err := client.Get(ctx, ttl, key, func(ctx context.Context, key string) (string, error) {
+		val, err := fn(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if isZero(val) {
+			return string([]byte{nilTag}), nil
+		}
+		b, err := client.codec.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(append([]byte{valueTag}, b...)), nil
+	})
+	if err != nil {
+		return zero, err
+	}
+	if raw == "" || raw[0] == nilTag {
+		return zero, nil
+	}
+	var out T
+	err = client.codec.Unmarshal([]byte(raw[1:]), &out)
+	return out, err
+}
+
+func isZero(v any) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	return rv.IsZero()
+}
@@ -0,0 +1,77 @@
+package rueidisaside
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGenericGetRoundTrip covers Get[T]'s codec-based path end to end: a
+// struct value filled once must come back identical on a subsequent hit,
+// without fn running again.
+func TestGenericGetRoundTrip(t *testing.T) {
+	c := newTestClient(t, ClientOption{})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+	want := codecTestValue{A: "hello", B: 42}
+
+	var calls int32
+	fn := func(ctx context.Context, key string) (codecTestValue, error) {
+		atomic.AddInt32(&calls, 1)
+		return want, nil
+	}
+
+	got, err := Get(ctx, c, time.Minute, key, fn)
+	if err != nil {
+		t.Fatalf("first Get: %v", err)
+	}
+	if got != want {
+		t.Fatalf("first Get: got %+v, want %+v", got, want)
+	}
+
+	got, err = Get(ctx, c, time.Minute, key, fn)
+	if err != nil {
+		t.Fatalf("second Get: %v", err)
+	}
+	if got != want {
+		t.Fatalf("second Get: got %+v, want %+v", got, want)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("fn called %d times, want 1 (second Get should have hit the cache)", n)
+	}
+}
+
+// TestGenericGetNilMarker covers the nilTag path: a filler that legitimately
+// returns T's zero value must be cached as the explicit nil marker, read
+// back as the zero value, and not re-run fn on the next hit.
+func TestGenericGetNilMarker(t *testing.T) {
+	c := newTestClient(t, ClientOption{})
+	ctx := context.Background()
+	key := uniqueKeys(t, 1)[0]
+
+	var calls int32
+	fn := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", nil // zero value for T=string
+	}
+
+	got, err := Get(ctx, c, time.Minute, key, fn)
+	if err != nil {
+		t.Fatalf("first Get: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("first Get: got %q, want zero value", got)
+	}
+
+	got, err = Get(ctx, c, time.Minute, key, fn)
+	if err != nil {
+		t.Fatalf("second Get: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("second Get: got %q, want zero value", got)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("fn called %d times, want 1 (the nil marker should have been served from cache)", n)
+	}
+}